@@ -0,0 +1,138 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package env
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxDotenvLine is the maximum length of a line read by readDotenv.
+const maxDotenvLine = 16 << 20
+
+// ReadError is returned when reading env definitions fails, as opposed to
+// parsing them, e.g. by ParseReader when the reader returns an error.
+type ReadError struct {
+	Err error
+}
+
+func (e *ReadError) Error() string { return "read error: " + e.Err.Error() }
+
+func (e *ReadError) Unwrap() error { return e.Err }
+
+// readDotenv reads KEY=VALUE lines in the dotenv format from r, line by line,
+// and returns them as KEY=VALUE envs. Blank lines and lines starting with #
+// are ignored, and keys may be preceded by "export ". Values may be enclosed
+// in single quotes, taken literally, or in double quotes, in which \n, \t,
+// \", and \\ are unescaped. Unquoted values end at a # preceded by whitespace.
+func readDotenv(r io.Reader) ([]string, error) {
+	var envs []string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, maxDotenvLine)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, err := parseDotenvLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		envs = append(envs, key+"="+value)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, &ReadError{Err: err}
+	}
+	return envs, nil
+}
+
+// parseDotenvLine parses a non-blank, non-comment dotenv line.
+func parseDotenvLine(line string) (key, value string, err error) {
+	line = strings.TrimPrefix(line, "export ")
+	i := strings.Index(line, "=")
+	if i < 0 {
+		return "", "", errors.New("missing =")
+	}
+	key, value = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return "", "", fmt.Errorf("invalid key %q", key)
+	}
+
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		if j := strings.Index(value, " #"); j >= 0 {
+			value = value[:j]
+		}
+		if j := strings.Index(value, "\t#"); j >= 0 {
+			value = value[:j]
+		}
+		return key, strings.TrimSpace(value), nil
+	}
+
+	quote := value[0]
+	var b strings.Builder
+	for j := 1; j < len(value); j++ {
+		c := value[j]
+		switch {
+		case c == quote:
+			rest := strings.TrimSpace(value[j+1:])
+			if rest != "" && !strings.HasPrefix(rest, "#") {
+				return "", "", fmt.Errorf("unexpected %q after closing quote", rest)
+			}
+			return key, b.String(), nil
+		case c == '\\' && quote == '"' && j+1 < len(value):
+			j++
+			b.WriteByte(unescape(value[j]))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", errors.New("unterminated quoted value")
+}
+
+// unquoteValue returns the value s without its enclosing quotes, if s is
+// entirely enclosed in matching single or double quotes, and s otherwise.
+// As in dotenv files, single-quoted values are taken literally, and \n, \t,
+// \", and \\ are unescaped in double-quoted values.
+func unquoteValue(s string) string {
+	if len(s) < 2 || (s[0] != '"' && s[0] != '\'') || s[len(s)-1] != s[0] {
+		return s
+	}
+	quote := s[0]
+	var b strings.Builder
+	for j := 1; j < len(s)-1; j++ {
+		c := s[j]
+		switch {
+		case c == quote:
+			// closed before the end, e.g. "a" "b".
+			return s
+		case c == '\\' && quote == '"':
+			j++
+			if j == len(s)-1 {
+				// the closing quote is escaped.
+				return s
+			}
+			b.WriteByte(unescape(s[j]))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// unescape returns the byte denoted by the escape sequence \c.
+func unescape(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	}
+	return c
+}
@@ -1,3 +1,3 @@
 module github.com/shaj13/env
 
-go 1.17
+go 1.21
@@ -0,0 +1,349 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !env_nojsonschema
+// +build !env_nojsonschema
+
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// JSONSchemaVar defines a JSON env with specified name, JSON schema, and usage string.
+// The argument p must be a pointer to a variable that will hold the value of the env,
+// its current value is the default value of the env.
+// The env value is validated against the schema and then unmarshaled into p
+// using json.Unmarshal. The schema supports the type, enum, properties, required,
+// additionalProperties, items, minimum, maximum, minLength, maxLength, minItems,
+// maxItems, and pattern keywords. JSONSchemaVar panics if the schema is invalid
+// or uses other keywords, which would otherwise be ignored.
+// JSONSchemaVar is left out of builds with the env_nojsonschema build tag.
+func (e *EnvSet) JSONSchemaVar(p interface{}, name, schema, usage string) {
+	e.Var(newJSONSchemaValue(p, schema), name, usage)
+}
+
+// JSONSchemaVar defines a JSON env with specified name, JSON schema, and usage string.
+// See the documentation for EnvSet.JSONSchemaVar for more information.
+func JSONSchemaVar(p interface{}, name, schema, usage string) {
+	Environ.Var(newJSONSchemaValue(p, schema), name, usage)
+}
+
+// -- JSON schema validated Value
+type jsonSchemaValue struct {
+	p      interface{}
+	schema jsonSchema
+}
+
+func newJSONSchemaValue(p interface{}, schema string) *jsonSchemaValue {
+	if reflect.ValueOf(p).Kind() != reflect.Ptr {
+		panic("variable value type must be a pointer")
+	}
+	s, err := parseJSONSchema(schema)
+	if err != nil {
+		panic(fmt.Sprintf("invalid json schema: %v", err))
+	}
+	return &jsonSchemaValue{p: p, schema: s}
+}
+
+func (j *jsonSchemaValue) Set(s string) error {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		return fmt.Errorf("%w: %v", ErrParse, err)
+	}
+	if err := j.schema.validate("$", doc); err != nil {
+		return fmt.Errorf("%w: %v", ErrParse, err)
+	}
+	if err := json.Unmarshal([]byte(s), j.p); err != nil {
+		return fmt.Errorf("%w: %v", ErrParse, err)
+	}
+	return nil
+}
+
+func (j *jsonSchemaValue) Get() interface{} { return j.p }
+
+func (j *jsonSchemaValue) typeName() string { return "json" }
+
+func (j *jsonSchemaValue) String() string {
+	if j.p == nil {
+		return ""
+	}
+	b, err := json.Marshal(j.p)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (j *jsonSchemaValue) keep() func() {
+	b, err := json.Marshal(j.p)
+	if err != nil {
+		return nil
+	}
+	return func() {
+		zero(j.p)
+		_ = json.Unmarshal(b, j.p)
+	}
+}
+
+func (j *jsonSchemaValue) shadow() (Value, func()) {
+	r := &replayValue{Value: &jsonSchemaValue{p: newOf(j.p), schema: j.schema}}
+	return r, r.replay(j)
+}
+
+// jsonSchema is a JSON schema document, it supports the following subset
+// of keywords: type, enum, properties, required, additionalProperties,
+// items, minimum, maximum, minLength, maxLength, minItems, maxItems,
+// and pattern.
+type jsonSchema map[string]interface{}
+
+// validate validates the decoded JSON value v against the schema
+// and returns the first violation found, path is the location of v
+// within the document and is used in the error message.
+func (s jsonSchema) validate(path string, v interface{}) error {
+	if t, ok := s["type"]; ok && !s.matchType(t, v) {
+		return fmt.Errorf("%s: expected %v, got %s", path, t, jsonType(v))
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value must be one of %v", path, enum)
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return s.validateObject(path, v)
+	case []interface{}:
+		return s.validateArray(path, v)
+	case string:
+		return s.validateString(path, v)
+	case float64:
+		return s.validateNumber(path, v)
+	}
+
+	return nil
+}
+
+func (s jsonSchema) validateObject(path string, v map[string]interface{}) error {
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, _ := r.(string); name != "" {
+				if _, ok := v[name]; !ok {
+					return fmt.Errorf("%s: missing required property %q", path, name)
+				}
+			}
+		}
+	}
+
+	props, _ := s["properties"].(map[string]interface{})
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sub, ok := props[name].(map[string]interface{})
+		if !ok {
+			if allowed, ok := s["additionalProperties"].(bool); ok && !allowed {
+				return fmt.Errorf("%s: unexpected property %q", path, name)
+			}
+			continue
+		}
+		if err := jsonSchema(sub).validate(path+"."+name, v[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s jsonSchema) validateArray(path string, v []interface{}) error {
+	if min, ok := s["minItems"].(float64); ok && float64(len(v)) < min {
+		return fmt.Errorf("%s: expected at least %v items, got %d", path, min, len(v))
+	}
+	if max, ok := s["maxItems"].(float64); ok && float64(len(v)) > max {
+		return fmt.Errorf("%s: expected at most %v items, got %d", path, max, len(v))
+	}
+	if items, ok := s["items"].(map[string]interface{}); ok {
+		for i, item := range v {
+			if err := jsonSchema(items).validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s jsonSchema) validateString(path string, v string) error {
+	n := float64(len([]rune(v)))
+	if min, ok := s["minLength"].(float64); ok && n < min {
+		return fmt.Errorf("%s: expected at least %v characters, got %v", path, min, n)
+	}
+	if max, ok := s["maxLength"].(float64); ok && n > max {
+		return fmt.Errorf("%s: expected at most %v characters, got %v", path, max, n)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern %q: %v", path, pattern, err)
+		}
+		if !re.MatchString(v) {
+			return fmt.Errorf("%s: %q does not match pattern %q", path, v, pattern)
+		}
+	}
+	return nil
+}
+
+func (s jsonSchema) validateNumber(path string, v float64) error {
+	if min, ok := s["minimum"].(float64); ok && v < min {
+		return fmt.Errorf("%s: %v is less than minimum %v", path, v, min)
+	}
+	if max, ok := s["maximum"].(float64); ok && v > max {
+		return fmt.Errorf("%s: %v is greater than maximum %v", path, v, max)
+	}
+	return nil
+}
+
+// matchType reports whether v is of the schema type t,
+// which is either a type name or a list of type names.
+func (s jsonSchema) matchType(t interface{}, v interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		if t == "integer" {
+			f, ok := v.(float64)
+			return ok && f == math.Trunc(f)
+		}
+		if t == "number" {
+			_, ok := v.(float64)
+			return ok
+		}
+		return t == jsonType(v)
+	case []interface{}:
+		for _, tt := range t {
+			if s.matchType(tt, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON schema type name of the decoded JSON value v.
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return strings.ToLower(reflect.TypeOf(v).Kind().String())
+}
+
+// jsonSchemaKeywords maps the supported keywords to the JSON type of their
+// value, annotations are accepted but not validated against.
+var jsonSchemaKeywords = map[string]string{
+	"type":                 "",
+	"enum":                 "array",
+	"properties":           "object",
+	"required":             "array",
+	"additionalProperties": "boolean",
+	"items":                "object",
+	"minimum":              "number",
+	"maximum":              "number",
+	"minLength":            "number",
+	"maxLength":            "number",
+	"minItems":             "number",
+	"maxItems":             "number",
+	"pattern":              "string",
+	"$schema":              "",
+	"$id":                  "",
+	"$comment":             "",
+	"title":                "",
+	"description":          "",
+	"default":              "",
+	"examples":             "",
+}
+
+// parseJSONSchema parses the given JSON schema document, rejecting
+// keywords that are not supported rather than ignoring them.
+func parseJSONSchema(schema string) (jsonSchema, error) {
+	var s jsonSchema
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		return nil, err
+	}
+	if err := s.check("$"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// check checks that the schema, found at path, and its subschemas
+// use only the supported keywords with values of the expected type.
+func (s jsonSchema) check(path string) error {
+	keywords := make([]string, 0, len(s))
+	for k := range s {
+		keywords = append(keywords, k)
+	}
+	sort.Strings(keywords)
+
+	for _, k := range keywords {
+		typ, ok := jsonSchemaKeywords[k]
+		if !ok {
+			return fmt.Errorf("%s: unsupported keyword %q", path, k)
+		}
+		if typ != "" && jsonType(s[k]) != typ {
+			return fmt.Errorf("%s: keyword %q must be of type %s", path, k, typ)
+		}
+	}
+
+	if pattern, ok := s["pattern"].(string); ok {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%s: invalid pattern %q: %v", path, pattern, err)
+		}
+	}
+	if items, ok := s["items"].(map[string]interface{}); ok {
+		if err := jsonSchema(items).check(path + ".items"); err != nil {
+			return err
+		}
+	}
+	props, _ := s["properties"].(map[string]interface{})
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sub, ok := props[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s.properties.%s: schema must be of type object", path, name)
+		}
+		if err := jsonSchema(sub).check(path + ".properties." + name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !env_nojsonschema
+// +build !env_nojsonschema
+
+package env_test
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/shaj13/env"
+)
+
+func TestJSONSchemaVar(t *testing.T) {
+	const schema = `{
+		"type": "object",
+		"required": ["name", "rules"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"rules": {
+				"type": "array",
+				"items": {"type": "integer", "minimum": 0, "maximum": 10}
+			}
+		}
+	}`
+	type policy struct {
+		Name  string `json:"name"`
+		Rules []int  `json:"rules"`
+	}
+
+	es := NewEnvSet("", ContinueOnError)
+	es.SetOutput(io.Discard)
+	var p policy
+	es.JSONSchemaVar(&p, "policy", schema, "policy document")
+	if err := es.Parse([]string{`POLICY={"name":"default","rules":[1,2]}`}); err != nil {
+		t.Fatal(err)
+	}
+	if want := (policy{Name: "default", Rules: []int{1, 2}}); !reflect.DeepEqual(p, want) {
+		t.Errorf("got %+v; want %+v", p, want)
+	}
+
+	tests := map[string]string{
+		`{"name":"x"}`:                         `$: missing required property "rules"`,
+		`{"name":"","rules":[]}`:               "$.name: expected at least 1 characters",
+		`{"name":"x","rules":[1.5]}`:           "$.rules[0]: expected integer, got number",
+		`{"name":"x","rules":[11]}`:            "$.rules[0]: 11 is greater than maximum 10",
+		`{"name":"x","rules":[],"extra":true}`: `$: unexpected property "extra"`,
+		`[]`:                                   "$: expected object, got array",
+		`{`:                                    "parse error",
+	}
+	for value, want := range tests {
+		err := es.Parse([]string{"POLICY=" + value})
+		if err == nil || !strings.Contains(err.Error(), want) || !errors.Is(err, ErrParse) {
+			t.Errorf("Parse(%q) = %v; want parse error containing %q", value, err, want)
+		}
+	}
+
+	schemas := map[string]string{
+		`{"oneOf": [{"type": "string"}]}`:                        `$: unsupported keyword "oneOf"`,
+		`{"properties": {"a": {"$ref": "#/definitions/a"}}}`:     `$.properties.a: unsupported keyword "$ref"`,
+		`{"items": {"type": "integer", "exclusiveMinimum": 0}}`:  `$.items: unsupported keyword "exclusiveMinimum"`,
+		`{"type": "string", "format": "email"}`:                  `$: unsupported keyword "format"`,
+		`{"additionalProperties": {"type": "string"}}`:           `$: keyword "additionalProperties" must be of type boolean`,
+		`{"type": "string", "pattern": "("}`:                     "$: invalid pattern",
+		`{"type": "array", "items": [{"type": "string"}]}`:       `$: keyword "items" must be of type object`,
+		`{"title": "policy", "description": "the policy"}`:       "",
+		`{"$schema": "http://json-schema.org/draft-07/schema#"}`: "",
+	}
+	for schema, want := range schemas {
+		func() {
+			defer func() {
+				msg, _ := recover().(string)
+				if want == "" && msg != "" || !strings.Contains(msg, want) {
+					t.Errorf("JSONSchemaVar(%s) panicked with %q; want %q", schema, msg, want)
+				}
+			}()
+			es.JSONSchemaVar(new(interface{}), "schema", schema, "")
+			es.Unset("schema")
+		}()
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package env
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Struct defines an env for each exported field of the struct pointed to by v.
+// The env name is given by the field's env tag, or else is the field name in
+// upper snake case, e.g. DatabaseURL is DATABASE_URL, and a tag of "-" skips
+// the field. The default value is given by the default tag and the usage string
+// by the usage tag:
+//
+//	type Config struct {
+//		Host    string        `env:"HOST" default:"localhost" usage:"server host"`
+//		Timeout time.Duration `default:"5s"`
+//		DB      struct {
+//			URL string `usage:"database url"`
+//		}
+//	}
+//
+// Fields of type string, bool, int, int32, int64, uint, uint16, uint32,
+// uint64, float64, and time.Duration, or whose address implements encoding.TextUnmarshaler,
+// are supported. The envs of the fields of a nested struct are prefixed with
+// the nested struct env name and an underscore, e.g. DB_URL, unless the struct
+// is embedded. Struct returns an error if v is not a non-nil pointer to
+// a struct, if a field has an unsupported type, or an invalid default.
+func (e *EnvSet) Struct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("env: Struct of non-pointer to struct %T", v)
+	}
+	return e.defineStruct(rv.Elem(), "", false)
+}
+
+// Struct defines an "Environ" env for each exported field of the struct pointed to by v.
+// See the documentation for EnvSet.Struct for more information.
+func Struct(v interface{}) error {
+	return Environ.Struct(v)
+}
+
+// StructWithDefaults is like Struct, except that the default value of each env
+// is the current value of its field, rather than the default tag, which is
+// ignored. This allows defaults to be given as Go values, e.g. by setting
+// cfg.Port to 8080 before the call. Fields left at their zero value have
+// the zero value of their type as default.
+func (e *EnvSet) StructWithDefaults(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("env: StructWithDefaults of non-pointer to struct %T", v)
+	}
+	return e.defineStruct(rv.Elem(), "", true)
+}
+
+// StructWithDefaults defines an "Environ" env for each exported field of the struct
+// pointed to by v, with the current field values as defaults.
+// See the documentation for EnvSet.StructWithDefaults for more information.
+func StructWithDefaults(v interface{}) error {
+	return Environ.StructWithDefaults(v)
+}
+
+// defineStruct defines an env for each exported field of the struct sv,
+// prefixing the env names with prefix. If live is true, the current
+// field values are the defaults, otherwise the default tags are.
+func (e *EnvSet) defineStruct(sv reflect.Value, prefix string, live bool) error {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		tag := f.Tag.Get("env")
+		if f.PkgPath != "" || tag == "-" {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = snakeCase(f.Name)
+		}
+		fv := sv.Field(i)
+		// keep the current value, as fieldValue zeroes the field.
+		cur := reflect.New(f.Type).Elem()
+		cur.Set(fv)
+
+		value := fieldValue(fv)
+		if value == nil && f.Type.Kind() == reflect.Struct {
+			nested := prefix + name + "_"
+			if f.Anonymous && tag == "" {
+				nested = prefix
+			}
+			if err := e.defineStruct(fv, nested, live); err != nil {
+				return err
+			}
+			continue
+		}
+		if value == nil {
+			return fmt.Errorf("env: field %s has unsupported type %s", f.Name, f.Type)
+		}
+
+		if live {
+			fv.Set(cur)
+		} else if def, ok := f.Tag.Lookup("default"); ok {
+			if err := value.Set(def); err != nil {
+				return fmt.Errorf("env: invalid default %q for field %s: %w", def, f.Name, err)
+			}
+		}
+		e.Var(value, prefix+name, f.Tag.Get("usage"))
+	}
+	return nil
+}
+
+// fieldValue returns a Value that stores into the addressable
+// struct field fv, set to its zero value, or nil if the type
+// of the field is not supported.
+func fieldValue(fv reflect.Value) Value {
+	p := fv.Addr()
+	if p.Type().Implements(textUnmarshalerType) {
+		fv.Set(reflect.Zero(fv.Type()))
+		return textValue{p.Interface().(encoding.TextUnmarshaler)}
+	}
+	if fv.Type() == durationType {
+		return newDurationValue(0, p.Interface().(*time.Duration))
+	}
+
+	// convert the pointer so that named types, e.g. type Mode string, are supported.
+	ptr := func(v interface{}) interface{} {
+		return p.Convert(reflect.TypeOf(v)).Interface()
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		return newStringValue("", ptr((*string)(nil)).(*string))
+	case reflect.Bool:
+		return newBoolValue(false, ptr((*bool)(nil)).(*bool))
+	case reflect.Int:
+		return newIntValue(0, ptr((*int)(nil)).(*int))
+	case reflect.Int32:
+		return newInt32Value(0, ptr((*int32)(nil)).(*int32))
+	case reflect.Int64:
+		return newInt64Value(0, ptr((*int64)(nil)).(*int64))
+	case reflect.Uint:
+		return newUintValue(0, ptr((*uint)(nil)).(*uint))
+	case reflect.Uint16:
+		return newUint16Value(0, ptr((*uint16)(nil)).(*uint16))
+	case reflect.Uint32:
+		return newUint32Value(0, ptr((*uint32)(nil)).(*uint32))
+	case reflect.Uint64:
+		return newUint64Value(0, ptr((*uint64)(nil)).(*uint64))
+	case reflect.Float64:
+		return newFloat64Value(0, ptr((*float64)(nil)).(*float64))
+	}
+	return nil
+}
+
+// Spec describes an env to be defined by Register.
+type Spec struct {
+	Name         string       // name of the env
+	Usage        string       // help message
+	Default      string       // default value as text; empty means the zero value
+	Kind         reflect.Kind // kind of the value, e.g. reflect.Int
+	Required     bool         // env must be set, see Required
+	Secret       bool         // value is redacted and must be set, see MarkSecret
+	AllowDefault bool         // secret may keep its default, see AllowDefaultSecret
+}
+
+// Register defines an env for each spec, e.g. loaded from a registry at
+// runtime, holding a value of the spec kind. The supported kinds are those
+// of the field types supported by Struct, i.e. String, Bool, Int, Int32,
+// Int64, Uint, Uint16, Uint32, Uint64, and Float64. Register returns an
+// error, without defining any env, if a spec has an unsupported kind,
+// an invalid default, or a name that is already defined or invalid.
+func (e *EnvSet) Register(specs ...Spec) error {
+	sep := e.pairSeparator()
+	names := make([]string, len(specs))
+	seen := make(map[string]bool, len(specs))
+	for i, spec := range specs {
+		if strings.Contains(spec.Name, sep) {
+			return fmt.Errorf("env: spec %s contains %s", spec.Name, sep)
+		}
+		names[i] = e.normalize(spec.Name)
+		if seen[names[i]] {
+			return fmt.Errorf("env: spec %s redefines env %s", spec.Name, names[i])
+		}
+		seen[names[i]] = true
+	}
+	e.mu.RLock()
+	for i, name := range names {
+		if _, ok := e.formal[name]; ok {
+			e.mu.RUnlock()
+			return fmt.Errorf("env: spec %s redefines env %s", specs[i].Name, name)
+		}
+	}
+	e.mu.RUnlock()
+
+	values := make([]Value, len(specs))
+	for i, spec := range specs {
+		var value Value
+		if t, ok := kindTypes[spec.Kind]; ok {
+			value = fieldValue(reflect.New(t).Elem())
+		}
+		if value == nil {
+			return fmt.Errorf("env: spec %s has unsupported kind %s", spec.Name, spec.Kind)
+		}
+		if spec.Default != "" {
+			if err := value.Set(spec.Default); err != nil {
+				return fmt.Errorf("env: invalid default %q for spec %s: %w", spec.Default, spec.Name, err)
+			}
+		}
+		values[i] = value
+	}
+
+	for i, spec := range specs {
+		e.Var(values[i], spec.Name, spec.Usage)
+		if spec.Required {
+			e.Required(spec.Name)
+		}
+		if spec.Secret {
+			e.MarkSecret(spec.Name)
+		}
+		if spec.AllowDefault {
+			e.AllowDefaultSecret(spec.Name)
+		}
+	}
+	return nil
+}
+
+// Register defines an "Environ" env for each spec.
+// See the documentation for EnvSet.Register for more information.
+func Register(specs ...Spec) error {
+	return Environ.Register(specs...)
+}
+
+// kindTypes maps the kinds supported by Register to their types.
+var kindTypes = map[reflect.Kind]reflect.Type{
+	reflect.String:  reflect.TypeOf(""),
+	reflect.Bool:    reflect.TypeOf(false),
+	reflect.Int:     reflect.TypeOf(0),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint:    reflect.TypeOf(uint(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Float64: reflect.TypeOf(0.0),
+}
+
+// snakeCase returns the upper snake case form of the
+// field name s, e.g. DatabaseURL is DATABASE_URL.
+func snakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}